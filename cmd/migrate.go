@@ -0,0 +1,80 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmd implements the "casvisor migrate" CLI subcommand, invoked from
+// main before the beego server starts.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/casbin/casvisor/object"
+	"github.com/casbin/casvisor/object/migrations"
+)
+
+// RunMigrateCommand handles "casvisor migrate up|down N|status". args is
+// os.Args[2:], i.e. with "casvisor" and "migrate" already stripped.
+func RunMigrateCommand(args []string) {
+	object.InitAdapter()
+
+	if len(args) == 0 {
+		fmt.Println("Usage: casvisor migrate [up|down N|status]")
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = object.RunMigrations()
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Printf("invalid rollback count %q: %v\n", args[1], err)
+				os.Exit(1)
+			}
+		}
+		err = migrations.Down(object.GetEngine(), n)
+	case "status":
+		err = printStatus()
+	default:
+		fmt.Printf("Unknown migrate subcommand: %s\n", args[0])
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func printStatus() error {
+	entries, err := migrations.Status(object.GetEngine())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		state := "pending"
+		if entry.Applied {
+			state = "applied"
+		}
+		fmt.Printf("%s  %-8s  %s\n", entry.ID, state, entry.Description)
+	}
+
+	return nil
+}
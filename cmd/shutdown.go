@@ -0,0 +1,39 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/casbin/casvisor/object"
+)
+
+// WaitForShutdown blocks until SIGINT or SIGTERM is received, then closes
+// the adapter's database connection before returning. main should run this
+// in its own goroutine (or call it after beego.Run() returns, depending on
+// how beego.Run is invoked) so the process no longer relies on
+// runtime.SetFinalizer to close the connection on GC.
+func WaitForShutdown() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	if err := object.CloseAdapter(); err != nil {
+		log.Printf("error closing adapter during shutdown: %v", err)
+	}
+}
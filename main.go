@@ -0,0 +1,42 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/beego/beego"
+	"github.com/casbin/casvisor/cmd"
+	"github.com/casbin/casvisor/object"
+)
+
+func main() {
+	// "casvisor migrate up|down N|status" drives migrations directly instead
+	// of starting the server; RunMigrateCommand initializes its own adapter,
+	// so skip the usual InitConfig startup path.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cmd.RunMigrateCommand(os.Args[2:])
+		return
+	}
+
+	object.InitConfig()
+
+	go func() {
+		cmd.WaitForShutdown()
+		os.Exit(0)
+	}()
+
+	beego.Run()
+}
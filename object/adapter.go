@@ -15,18 +15,27 @@
 package object
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
 	"fmt"
-	"runtime"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/casbin/casvisor/object/migrations"
 	"github.com/casbin/casvisor/util"
 
 	"github.com/beego/beego"
-	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"xorm.io/core"
 	"xorm.io/xorm"
+	"xorm.io/xorm/contexts"
 )
 
 var adapter *Adapter
@@ -38,24 +47,64 @@ func InitConfig() {
 	}
 
 	InitAdapter()
-	CreateTables()
+
+	err = RunMigrations()
+	if err != nil {
+		panic(err)
+	}
 }
 
+// initAdapterRetries and initAdapterBaseDelay bound how long InitAdapter
+// tolerates the database being unreachable at boot, e.g. because it is still
+// starting up alongside casvisor in the same compose/k8s rollout.
+const (
+	initAdapterRetries     = 5
+	initAdapterBaseDelay   = 1 * time.Second
+	initAdapterPingTimeout = 5 * time.Second
+)
+
 func InitAdapter() {
-	adapter = NewAdapter(beego.AppConfig.String("driverName"), beego.AppConfig.String("dataSourceName"))
+	cfg := adapterConfigFromAppConfig(beego.AppConfig.String("driverName"), beego.AppConfig.String("dataSourceName"))
+
+	var a *Adapter
+	var err error
+	for attempt := 1; attempt <= initAdapterRetries; attempt++ {
+		a, err = NewAdapterContext(context.Background(), cfg, initAdapterPingTimeout)
+		if err == nil {
+			break
+		}
+
+		log.Printf("InitAdapter: attempt %d/%d to connect to the database failed: %v", attempt, initAdapterRetries, err)
+		if attempt < initAdapterRetries {
+			delay := initAdapterBaseDelay * time.Duration(1<<uint(attempt-1))
+			time.Sleep(delay)
+		}
+	}
+	if err != nil {
+		panic(fmt.Errorf("InitAdapter: giving up after %d attempts: %w", initAdapterRetries, err))
+	}
+
+	adapter = a
 
 	tableNamePrefix := beego.AppConfig.String("tableNamePrefix")
 	tbMapper := core.NewPrefixMapper(core.SnakeMapper{}, tableNamePrefix)
 	adapter.engine.SetTableMapper(tbMapper)
 }
 
-func CreateTables() {
-	err := adapter.createDatabase()
-	if err != nil {
-		panic(err)
-	}
+// RunMigrations applies any registered schema migrations that have not yet
+// been recorded as applied. The migrations package is now the sole owner of
+// table creation: it replaces the Sync2-based table setup CreateTables used
+// to do directly, since the two raced to create the same dataset/record/
+// asset tables (Sync2 is idempotent, but the migrations package's
+// CreateTable is not, so running both panicked on every restart).
+func RunMigrations() error {
+	return migrations.RunMigrations(adapter.engine)
+}
 
-	adapter.createTable()
+// GetEngine returns the underlying xorm engine, for callers such as the
+// "casvisor migrate" CLI subcommand that need to drive migrations directly.
+func GetEngine() *xorm.Engine {
+	return adapter.engine
 }
 
 // Adapter represents the MySQL adapter for policy storage.
@@ -63,46 +112,182 @@ type Adapter struct {
 	driverName     string
 	dataSourceName string
 	engine         *xorm.Engine
+	config         AdapterConfig
+	tlsConfigName  string
 }
 
-// finalizer is the destructor for Adapter.
-func finalizer(a *Adapter) {
-	err := a.engine.Close()
+// AdapterConfig carries the connection-pool and TLS options used by
+// NewAdapterWithConfig to build the Adapter's xorm.Engine.
+type AdapterConfig struct {
+	DriverName      string
+	DataSourceName  string
+	DBName          string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ShowSQL         bool
+	SlowThreshold   time.Duration
+	TLSConfig       *tls.Config
+}
+
+// adapterConfigFromAppConfig builds an AdapterConfig from beego.AppConfig, so
+// NewAdapter and InitAdapter's retry loop read the same settings.
+func adapterConfigFromAppConfig(driverName, dataSourceName string) AdapterConfig {
+	return AdapterConfig{
+		DriverName:      driverName,
+		DataSourceName:  dataSourceName,
+		DBName:          beego.AppConfig.String("dbName"),
+		MaxOpenConns:    beego.AppConfig.DefaultInt("maxOpenConns", 0),
+		MaxIdleConns:    beego.AppConfig.DefaultInt("maxIdleConns", 2),
+		ConnMaxLifetime: time.Duration(beego.AppConfig.DefaultInt("connMaxLifetimeSeconds", 0)) * time.Second,
+		ShowSQL:         beego.AppConfig.DefaultBool("showSql", false),
+		SlowThreshold:   time.Duration(beego.AppConfig.DefaultInt("slowThresholdMs", 0)) * time.Millisecond,
+	}
+}
+
+// NewAdapter is the constructor for Adapter. It reads connection-pool
+// settings from beego.AppConfig, so existing callers keep working
+// unchanged; it reads no TLS setting and leaves TLSConfig nil. Callers that need explicit control, TLS included, should use
+// NewAdapterWithConfig instead. It panics if the initial connection cannot
+// be established; InitAdapter uses NewAdapterContext instead so that a
+// database that isn't up yet doesn't crash the process.
+func NewAdapter(driverName string, dataSourceName string) *Adapter {
+	return NewAdapterWithConfig(adapterConfigFromAppConfig(driverName, dataSourceName))
+}
+
+// NewAdapterWithConfig is the options-based constructor for Adapter. It
+// panics if the initial connection cannot be established.
+func NewAdapterWithConfig(cfg AdapterConfig) *Adapter {
+	a, err := newAdapter(context.Background(), cfg, 0)
 	if err != nil {
 		panic(err)
 	}
+	return a
 }
 
-// NewAdapter is the constructor for Adapter.
-func NewAdapter(driverName string, dataSourceName string) *Adapter {
+// NewAdapterContext is like NewAdapterWithConfig, but returns an error
+// instead of panicking when the initial connection cannot be established,
+// so callers such as InitAdapter can retry with backoff. pingTimeout bounds
+// how long the startup PingContext call is allowed to take; zero means no
+// timeout beyond ctx's own deadline.
+func NewAdapterContext(ctx context.Context, cfg AdapterConfig, pingTimeout time.Duration) (*Adapter, error) {
+	return newAdapter(ctx, cfg, pingTimeout)
+}
+
+func newAdapter(ctx context.Context, cfg AdapterConfig, pingTimeout time.Duration) (*Adapter, error) {
 	a := &Adapter{}
-	a.driverName = driverName
-	a.dataSourceName = dataSourceName
+	a.driverName = cfg.DriverName
+	a.dataSourceName = cfg.DataSourceName
+	a.config = cfg
+
+	if cfg.TLSConfig != nil && cfg.DriverName == "mysql" {
+		a.tlsConfigName = "casvisor-" + cfg.DriverName
+		if err := mysql.RegisterTLSConfig(a.tlsConfigName, cfg.TLSConfig); err != nil {
+			return nil, err
+		}
+	}
 
-	// Open the DB, create it if not existed.
-	a.open()
+	// Create the database (or, for sqlite3, its parent directory) before
+	// opening the working connection: openContext's PingContext call needs
+	// something to actually connect to, and on a brand-new install nothing
+	// has created it yet.
+	if err := a.createDatabase(); err != nil {
+		return nil, err
+	}
 
-	// Call the destructor when the object is released.
-	runtime.SetFinalizer(a, finalizer)
+	if err := a.openContext(ctx, pingTimeout); err != nil {
+		return nil, err
+	}
+	a.applyPoolConfig()
 
-	return a
+	return a, nil
+}
+
+// Close releases the Adapter's underlying database connection. Callers
+// should invoke it once during graceful shutdown. It replaces the previous
+// runtime.SetFinalizer-based cleanup, which depended on the GC running at an
+// unpredictable time and panicked on error instead of surfacing it.
+func (a *Adapter) Close() error {
+	if a.engine == nil {
+		return nil
+	}
+
+	err := a.engine.Close()
+	a.engine = nil
+	return err
+}
+
+// CloseAdapter closes the package-level adapter used by InitAdapter. It is
+// meant to be wired into a graceful-shutdown hook triggered on SIGTERM or
+// SIGINT.
+func CloseAdapter() error {
+	if adapter == nil {
+		return nil
+	}
+	return adapter.Close()
+}
+
+// applyPoolConfig wires AdapterConfig's pool settings into the engine. Zero
+// values are left at the xorm/database-driver default.
+func (a *Adapter) applyPoolConfig() {
+	if a.engine == nil {
+		return
+	}
+
+	if a.config.MaxOpenConns > 0 {
+		a.engine.SetMaxOpenConns(a.config.MaxOpenConns)
+	}
+	if a.config.MaxIdleConns > 0 {
+		a.engine.SetMaxIdleConns(a.config.MaxIdleConns)
+	}
+	if a.config.ConnMaxLifetime > 0 {
+		a.engine.SetConnMaxLifetime(a.config.ConnMaxLifetime)
+	}
+	a.engine.ShowSQL(a.config.ShowSQL)
+
+	if a.config.SlowThreshold > 0 {
+		a.engine.AddHook(&slowQueryHook{threshold: a.config.SlowThreshold})
+	}
+}
+
+// slowQueryHook logs any statement whose execution time exceeds threshold.
+// It is registered with Engine.AddHook rather than via a custom logger,
+// since xorm's logger only receives the duration pre-formatted into the log
+// message, not as a comparable value.
+type slowQueryHook struct {
+	threshold time.Duration
+}
+
+func (h *slowQueryHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+func (h *slowQueryHook) AfterProcess(c *contexts.ContextHook) error {
+	if c.ExecuteTime >= h.threshold {
+		log.Printf("slow query (%s >= %s threshold): %s %v", c.ExecuteTime, h.threshold, c.SQL, c.Args)
+	}
+	return nil
 }
 
 func (a *Adapter) createDatabase() error {
-	dbName := beego.AppConfig.String("dbName")
+	dbName := a.config.DBName
 
 	switch a.driverName {
 	case "mysql":
 		return a.createDatabaseForMySQL(dbName)
 	case "postgres":
 		return a.createDatabaseForPostgres(dbName)
+	case "sqlite3":
+		return a.createDatabaseForSqlite3(dbName)
+	case "mssql":
+		return a.createDatabaseForMssql(dbName)
 	default:
 		return nil
 	}
 }
 
 func (a *Adapter) createDatabaseForMySQL(dbName string) error {
-	dsn := a.dataSourceName + "mysql"
+	dsn := buildMySQLCreateDataSourceName(a.dataSourceName)
 	engine, err := xorm.NewEngine(a.driverName, dsn)
 	if err != nil {
 		return err
@@ -115,7 +300,7 @@ func (a *Adapter) createDatabaseForMySQL(dbName string) error {
 }
 
 func (a *Adapter) createDatabaseForPostgres(dbName string) error {
-	dsn := strings.ReplaceAll(a.dataSourceName, dbName, "postgres")
+	dsn := buildPostgresCreateDataSourceName(a.dataSourceName, dbName)
 	engine, err := xorm.NewEngine(a.driverName, dsn)
 	if err != nil {
 		return err
@@ -153,16 +338,48 @@ func (a *Adapter) createDatabaseForPostgres(dbName string) error {
 	return nil
 }
 
-func (a *Adapter) open() {
-	dsn := a.dataSourceName
-	if a.driverName == "mysql" {
-		dsn = a.dataSourceName + beego.AppConfig.String("dbName")
+func (a *Adapter) createDatabaseForSqlite3(dbName string) error {
+	dir := filepath.Dir(a.dataSourceName)
+	return os.MkdirAll(dir, os.ModePerm)
+}
+
+func (a *Adapter) createDatabaseForMssql(dbName string) error {
+	dsn := buildMssqlCreateDataSourceName(a.dataSourceName, dbName)
+	engine, err := xorm.NewEngine(a.driverName, dsn)
+	if err != nil {
+		return err
 	}
+	defer engine.Close()
+
+	_, err = engine.Exec(fmt.Sprintf("IF DB_ID('%s') IS NULL CREATE DATABASE %s", dbName, dbName))
+
+	return err
+}
+
+// openContext opens the working connection, pinging it with pingTimeout (if
+// positive) instead of assuming a successful xorm.NewEngine call means the
+// database is actually reachable. It returns an error rather than panicking,
+// so callers can retry with backoff instead of crashing at startup.
+func (a *Adapter) openContext(ctx context.Context, pingTimeout time.Duration) error {
+	dsn := buildOpenDataSourceName(a.driverName, a.dataSourceName, a.config.DBName)
+	dsn = applyTLSToDataSourceName(a.driverName, dsn, a.tlsConfigName, a.config.TLSConfig)
 
 	engine, err := xorm.NewEngine(a.driverName, dsn)
 	if err != nil {
-		panic(err)
+		return err
 	}
+
+	pingCtx := ctx
+	if pingTimeout > 0 {
+		var cancel context.CancelFunc
+		pingCtx, cancel = context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+	}
+	if err := engine.PingContext(pingCtx); err != nil {
+		engine.Close()
+		return err
+	}
+
 	if a.driverName == "postgres" {
 		schema := util.GetParamFromDataSourceName(a.dataSourceName, "search_path")
 		if schema != "" {
@@ -170,27 +387,60 @@ func (a *Adapter) open() {
 		}
 	}
 	a.engine = engine
+	return nil
 }
 
-func (a *Adapter) close() {
-	a.engine.Close()
-	a.engine = nil
+// buildOpenDataSourceName returns the DSN used to open the working connection
+// for the given driver. MySQL needs the database name appended to the DSN
+// prefix; the other dialects embed the database in the DSN already.
+func buildOpenDataSourceName(driverName, dataSourceName, dbName string) string {
+	if driverName == "mysql" {
+		return dataSourceName + dbName
+	}
+	return dataSourceName
 }
 
-func (a *Adapter) createTable() {
-	err := a.engine.Sync2(new(Dataset))
-	if err != nil {
-		panic(err)
-	}
+// buildMySQLCreateDataSourceName returns the DSN used to connect without a
+// default database selected, so that CREATE DATABASE can be issued.
+func buildMySQLCreateDataSourceName(dataSourceName string) string {
+	return dataSourceName + "mysql"
+}
 
-	err = a.engine.Sync2(new(Record))
-	if err != nil {
-		panic(err)
+// buildPostgresCreateDataSourceName swaps the target database name in the DSN
+// for the always-present "postgres" database, so CREATE DATABASE can be issued.
+func buildPostgresCreateDataSourceName(dataSourceName, dbName string) string {
+	return strings.ReplaceAll(dataSourceName, dbName, "postgres")
+}
+
+// buildMssqlCreateDataSourceName swaps the target database in the DSN for the
+// always-present "master" database, so CREATE DATABASE can be issued.
+func buildMssqlCreateDataSourceName(dataSourceName, dbName string) string {
+	return strings.ReplaceAll(dataSourceName, fmt.Sprintf("database=%s", dbName), "database=master")
+}
+
+// applyTLSToDataSourceName rewrites dsn to request the given TLS config.
+// MySQL takes the name under which the config was registered via
+// mysql.RegisterTLSConfig; Postgres takes sslmode directly, since it has no
+// separate registration step.
+func applyTLSToDataSourceName(driverName, dsn, tlsConfigName string, tlsConfig *tls.Config) string {
+	if tlsConfig == nil {
+		return dsn
 	}
 
-	err = a.engine.Sync2(new(Asset))
-	if err != nil {
-		panic(err)
+	switch driverName {
+	case "mysql":
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return dsn + sep + "tls=" + tlsConfigName
+	case "postgres":
+		if !strings.Contains(dsn, "sslmode=") {
+			dsn += " sslmode=require"
+		}
+		return dsn
+	default:
+		return dsn
 	}
 }
 
@@ -0,0 +1,109 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"xorm.io/xorm/contexts"
+)
+
+func TestApplyTLSToDataSourceNameNoConfig(t *testing.T) {
+	dsn := applyTLSToDataSourceName("mysql", "root:123@tcp(localhost:3306)/casvisor", "casvisor-mysql", nil)
+	want := "root:123@tcp(localhost:3306)/casvisor"
+	if dsn != want {
+		t.Errorf("applyTLSToDataSourceName() with nil config = %q, want %q", dsn, want)
+	}
+}
+
+func TestApplyTLSToDataSourceNameMySQL(t *testing.T) {
+	cfg := &tls.Config{}
+
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"no existing query", "root:123@tcp(localhost:3306)/casvisor", "root:123@tcp(localhost:3306)/casvisor?tls=casvisor-mysql"},
+		{"existing query", "root:123@tcp(localhost:3306)/casvisor?parseTime=true", "root:123@tcp(localhost:3306)/casvisor?parseTime=true&tls=casvisor-mysql"},
+	}
+
+	for _, test := range tests {
+		got := applyTLSToDataSourceName("mysql", test.dsn, "casvisor-mysql", cfg)
+		if got != test.want {
+			t.Errorf("%s: applyTLSToDataSourceName() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestApplyTLSToDataSourceNamePostgres(t *testing.T) {
+	cfg := &tls.Config{}
+
+	dsn := applyTLSToDataSourceName("postgres", "user=postgres host=localhost dbname=casvisor", "", cfg)
+	want := "user=postgres host=localhost dbname=casvisor sslmode=require"
+	if dsn != want {
+		t.Errorf("applyTLSToDataSourceName() = %q, want %q", dsn, want)
+	}
+
+	dsn = applyTLSToDataSourceName("postgres", "user=postgres host=localhost dbname=casvisor sslmode=verify-full", "", cfg)
+	want = "user=postgres host=localhost dbname=casvisor sslmode=verify-full"
+	if dsn != want {
+		t.Errorf("applyTLSToDataSourceName() should not override an explicit sslmode, got %q", dsn)
+	}
+}
+
+func TestApplyPoolConfigSkipsZeroValues(t *testing.T) {
+	a := &Adapter{config: AdapterConfig{}}
+
+	// applyPoolConfig must not dereference a.engine for zero-value settings,
+	// since a caller constructing an Adapter directly (as in this test) has
+	// no engine yet.
+	a.applyPoolConfig()
+}
+
+func TestCreateDatabaseUnknownDriverIsNoOp(t *testing.T) {
+	// createDatabase reads dbName from a.config rather than the global
+	// beego.AppConfig; this test only checks that an unrecognized driver
+	// creates nothing and returns nil.
+	a :=&Adapter{driverName: "unknown-driver", config: AdapterConfig{DBName: "casvisor"}}
+
+	if err := a.createDatabase(); err != nil {
+		t.Errorf("createDatabase() with an unrecognized driver should no-op, got error: %v", err)
+	}
+}
+
+func TestSlowQueryHookLogsOverThreshold(t *testing.T) {
+	hook := &slowQueryHook{threshold: 100 * time.Millisecond}
+
+	ctx := context.Background()
+	gotCtx, err := hook.BeforeProcess(&contexts.ContextHook{Ctx: ctx})
+	if err != nil {
+		t.Fatalf("BeforeProcess() returned error: %v", err)
+	}
+	if gotCtx != ctx {
+		t.Error("BeforeProcess() should return the context unchanged")
+	}
+
+	// AfterProcess must not error regardless of whether the query was slow.
+	if err := hook.AfterProcess(&contexts.ContextHook{Ctx: ctx, SQL: "SELECT 1", ExecuteTime: 50 * time.Millisecond}); err != nil {
+		t.Errorf("AfterProcess() under threshold returned error: %v", err)
+	}
+	if err := hook.AfterProcess(&contexts.ContextHook{Ctx: ctx, SQL: "SELECT 1", ExecuteTime: 200 * time.Millisecond}); err != nil {
+		t.Errorf("AfterProcess() over threshold returned error: %v", err)
+	}
+}
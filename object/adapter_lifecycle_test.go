@@ -0,0 +1,68 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestNewAdapterContextAndClose(t *testing.T) {
+	a, err := NewAdapterContext(context.Background(), AdapterConfig{
+		DriverName:     "sqlite3",
+		DataSourceName: "file::memory:?cache=shared",
+	}, time.Second)
+	if err != nil {
+		t.Fatalf("NewAdapterContext() returned error: %v", err)
+	}
+
+	if err := a.Close(); err != nil {
+		t.Errorf("Close() returned error: %v", err)
+	}
+
+	// Close must be safe to call more than once.
+	if err := a.Close(); err != nil {
+		t.Errorf("second Close() returned error: %v", err)
+	}
+}
+
+func TestNewAdapterContextPingTimeout(t *testing.T) {
+	// An already-expired context must surface as an error from
+	// NewAdapterContext instead of panicking, since transient startup
+	// unavailability should be retriable by the caller.
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	_, err := NewAdapterContext(ctx, AdapterConfig{
+		DriverName:     "sqlite3",
+		DataSourceName: "file::memory:?cache=shared",
+	}, 0)
+	if err == nil {
+		t.Error("NewAdapterContext() with an expired context should return an error")
+	}
+}
+
+func TestCloseAdapterWithNilAdapter(t *testing.T) {
+	prevAdapter := adapter
+	adapter = nil
+	defer func() { adapter = prevAdapter }()
+
+	if err := CloseAdapter(); err != nil {
+		t.Errorf("CloseAdapter() with nil adapter returned error: %v", err)
+	}
+}
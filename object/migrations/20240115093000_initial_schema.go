@@ -0,0 +1,78 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+func init() {
+	Register(&Migration{
+		ID:          "20240115093000",
+		Description: "create initial dataset, record and asset tables",
+		Migrate: func(session *xorm.Session) error {
+			if err := session.CreateTable(new(datasetTable)); err != nil {
+				return err
+			}
+			if err := session.CreateTable(new(recordTable)); err != nil {
+				return err
+			}
+			return session.CreateTable(new(assetTable))
+		},
+		Rollback: func(session *xorm.Session) error {
+			if err := session.DropTable(new(datasetTable)); err != nil {
+				return err
+			}
+			if err := session.DropTable(new(recordTable)); err != nil {
+				return err
+			}
+			return session.DropTable(new(assetTable))
+		},
+	})
+}
+
+// datasetTable, recordTable and assetTable mirror the shape of
+// object.Dataset, object.Record and object.Asset at the time this migration
+// was written. Migrations must not reference the live model structs, since
+// those structs are free to change in later migrations.
+type datasetTable struct {
+	Owner       string `xorm:"varchar(100) notnull pk"`
+	Name        string `xorm:"varchar(100) notnull pk"`
+	CreatedTime string `xorm:"varchar(100)"`
+}
+
+func (datasetTable) TableName() string {
+	return "dataset"
+}
+
+type recordTable struct {
+	Owner       string `xorm:"varchar(100) notnull pk"`
+	Name        string `xorm:"varchar(100) notnull pk"`
+	CreatedTime string `xorm:"varchar(100)"`
+}
+
+func (recordTable) TableName() string {
+	return "record"
+}
+
+type assetTable struct {
+	Owner       string `xorm:"varchar(100) notnull pk"`
+	Name        string `xorm:"varchar(100) notnull pk"`
+	CreatedTime string `xorm:"varchar(100)"`
+}
+
+func (assetTable) TableName() string {
+	return "asset"
+}
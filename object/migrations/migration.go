@@ -0,0 +1,201 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrations implements a small xormigrate-style schema-migration
+// subsystem: each migration is registered via init() into a package-level
+// slice, and RunMigrations() applies whichever of them have not yet been
+// recorded in the casvisor_migrations table, in ascending ID order.
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// Migration is a single schema-migration step. ID should be a sortable
+// timestamp such as "20240115093000" so migrations apply in the order they
+// were authored. Migrate and Rollback receive a *xorm.Session that
+// RunMigrations/Down have already begun a transaction on, so a migration's
+// statements and its casvisor_migrations bookkeeping commit or roll back
+// together.
+type Migration struct {
+	ID          string
+	Description string
+	Migrate     func(session *xorm.Session) error
+	Rollback    func(session *xorm.Session) error
+}
+
+// migrations is the registry that migration files populate via init(), so
+// contributors can add a new migration without touching this file.
+var migrations []*Migration
+
+// Register adds a migration to the registry. It panics on a duplicate ID
+// since that means two migrations were generated from the same template.
+func Register(m *Migration) {
+	for _, existing := range migrations {
+		if existing.ID == m.ID {
+			panic(fmt.Sprintf("migrations: ID %s is already registered", m.ID))
+		}
+	}
+	migrations = append(migrations, m)
+}
+
+// migrationRecord is persisted to the casvisor_migrations table once a
+// migration has been applied successfully.
+type migrationRecord struct {
+	Id          string    `xorm:"pk varchar(14) 'id'"`
+	Description string    `xorm:"varchar(255)"`
+	AppliedAt   time.Time `xorm:"created"`
+}
+
+func (migrationRecord) TableName() string {
+	return "casvisor_migrations"
+}
+
+// sorted returns the registered migrations ordered by ascending ID.
+func sorted() []*Migration {
+	res := make([]*Migration, len(migrations))
+	copy(res, migrations)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// RunMigrations applies all migrations that are not yet recorded in the
+// casvisor_migrations table, in ascending ID order. Each migration runs
+// inside its own transaction together with the casvisor_migrations row that
+// records it as applied, so a crash or error partway through never leaves a
+// migration's statements committed without the bookkeeping that prevents it
+// from running again.
+func RunMigrations(engine *xorm.Engine) error {
+	if err := engine.Sync2(new(migrationRecord)); err != nil {
+		return err
+	}
+
+	for _, m := range sorted() {
+		applied, err := isApplied(engine, m.ID)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		if err := runInTransaction(engine, func(session *xorm.Session) error {
+			if err := m.Migrate(session); err != nil {
+				return err
+			}
+			_, err := session.Insert(&migrationRecord{Id: m.ID, Description: m.Description})
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %s (%s) failed: %w", m.ID, m.Description, err)
+		}
+	}
+
+	return nil
+}
+
+// Down rolls back the n most recently applied migrations, in descending ID
+// order. Each rollback runs inside its own transaction together with the
+// removal of its casvisor_migrations row, for the same reason RunMigrations
+// does.
+func Down(engine *xorm.Engine, n int) error {
+	if err := engine.Sync2(new(migrationRecord)); err != nil {
+		return err
+	}
+
+	all := sorted()
+	for i := len(all) - 1; i >= 0 && n > 0; i-- {
+		m := all[i]
+
+		applied, err := isApplied(engine, m.ID)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			continue
+		}
+
+		if m.Rollback == nil {
+			return fmt.Errorf("migration %s (%s) has no Rollback defined", m.ID, m.Description)
+		}
+
+		if err := runInTransaction(engine, func(session *xorm.Session) error {
+			if err := m.Rollback(session); err != nil {
+				return err
+			}
+			_, err := session.Delete(&migrationRecord{Id: m.ID})
+			return err
+		}); err != nil {
+			return fmt.Errorf("rollback of migration %s (%s) failed: %w", m.ID, m.Description, err)
+		}
+
+		n--
+	}
+
+	return nil
+}
+
+// runInTransaction begins a session, runs fn, and commits only if fn
+// succeeds, rolling back otherwise.
+func runInTransaction(engine *xorm.Engine, fn func(session *xorm.Session) error) error {
+	session := engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		return err
+	}
+
+	if err := fn(session); err != nil {
+		session.Rollback()
+		return err
+	}
+
+	return session.Commit()
+}
+
+// StatusEntry describes one registered migration's applied state, for use by
+// the "casvisor migrate status" subcommand.
+type StatusEntry struct {
+	ID          string
+	Description string
+	Applied     bool
+}
+
+// Status reports the applied state of every registered migration, in
+// ascending ID order.
+func Status(engine *xorm.Engine) ([]StatusEntry, error) {
+	if err := engine.Sync2(new(migrationRecord)); err != nil {
+		return nil, err
+	}
+
+	var entries []StatusEntry
+	for _, m := range sorted() {
+		applied, err := isApplied(engine, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, StatusEntry{ID: m.ID, Description: m.Description, Applied: applied})
+	}
+
+	return entries, nil
+}
+
+func isApplied(engine *xorm.Engine, id string) (bool, error) {
+	return engine.Where("id = ?", id).Get(new(migrationRecord))
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/casbin/casvisor/util"
+
+	"xorm.io/xorm"
+)
+
+// tsQuerySpecialChars strips the characters to_tsquery treats as operators
+// (&, |, !, (, ), :, *, ') out of a free-text search term, so a term that
+// happens to contain one doesn't corrupt the query we build around it.
+var tsQuerySpecialChars = strings.NewReplacer("&", "", "|", "", "!", "", "(", "", ")", "", ":", "", "*", "", "'", "")
+
+// toTSQuery turns free text like "vpn login failure" into the AND-joined
+// tsquery "vpn & login & failure" that to_tsquery expects. to_tsquery has no
+// free-text mode of its own: passing it space-separated words directly is a
+// syntax error for anything but a single term. Returns "" if q has no usable
+// terms.
+func toTSQuery(q string) string {
+	fields := strings.Fields(q)
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		term := tsQuerySpecialChars.Replace(field)
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// cursorPayload is the decoded contents of an opaque pagination cursor: the
+// sort column's value and the id of the last row of the previous page.
+type cursorPayload struct {
+	SortValue string `json:"s"`
+	Id        string `json:"id"`
+}
+
+// EncodeCursor builds the opaque cursor string for a row's sort-column value
+// and id, for use as the cursor argument to the next call to
+// GetSessionCursor.
+func EncodeCursor(sortValue, id string) string {
+	data, _ := json.Marshal(cursorPayload{SortValue: sortValue, Id: id})
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reverses EncodeCursor. An empty cursor decodes to a zero
+// cursorPayload, meaning "start from the beginning".
+func decodeCursor(cursor string) (cursorPayload, error) {
+	if cursor == "" {
+		return cursorPayload{}, nil
+	}
+
+	data, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return cursorPayload{}, err
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return cursorPayload{}, err
+	}
+
+	return payload, nil
+}
+
+// GetSessionCursor returns a session for cursor-based pagination, ordered by
+// (sortField, id) so that rows with a duplicate sortField value are still
+// ordered deterministically. Pass the empty string as cursor for the first
+// page; for subsequent pages, pass EncodeCursor(lastRow.sortField,
+// lastRow.Id) of the last row returned by the previous page.
+func GetSessionCursor(owner string, cursor string, limit int, sortField, sortOrder string) (*xorm.Session, error) {
+	if sortField == "" {
+		sortField = "created_time"
+	}
+	column := util.SnakeString(sortField)
+
+	payload, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	session := adapter.engine.Prepare()
+	if owner != "" {
+		session = session.And("owner=?", owner)
+	}
+
+	if payload.Id != "" {
+		if sortOrder == "ascend" {
+			session = session.And(fmt.Sprintf("(%s, id) > (?, ?)", column), payload.SortValue, payload.Id)
+		} else {
+			session = session.And(fmt.Sprintf("(%s, id) < (?, ?)", column), payload.SortValue, payload.Id)
+		}
+	}
+
+	if sortOrder == "ascend" {
+		session = session.Asc(column).Asc("id")
+	} else {
+		session = session.Desc(column).Desc("id")
+	}
+
+	if limit > 0 {
+		session = session.Limit(limit)
+	}
+
+	return session, nil
+}
+
+// GetSessionSearch builds on GetSession's single field/value filter by also
+// accepting a multi-field filter map and an optional free-text q term,
+// expanded with OR across searchColumns. searchColumns must be a whitelist
+// supplied by the caller, not derived from user input. When the adapter's
+// driver is Postgres and tsColumn is non-empty, q is matched against
+// tsColumn with to_tsquery instead of LIKE.
+func GetSessionSearch(owner string, offset, limit int, filters map[string]string, q string, searchColumns []string, tsColumn string, sortField, sortOrder string) *xorm.Session {
+	session := adapter.engine.Prepare()
+	if offset != -1 && limit != -1 {
+		session.Limit(limit, offset)
+	}
+	if owner != "" {
+		session = session.And("owner=?", owner)
+	}
+
+	for field, value := range filters {
+		if value == "" || !util.FilterField(field) {
+			continue
+		}
+		session = session.And(fmt.Sprintf("%s like ?", util.SnakeString(field)), fmt.Sprintf("%%%s%%", value))
+	}
+
+	if q != "" {
+		session = applyFreeTextSearch(session, q, searchColumns, tsColumn)
+	}
+
+	if sortField == "" || sortOrder == "" {
+		sortField = "created_time"
+	}
+	if sortOrder == "ascend" {
+		session = session.Asc(util.SnakeString(sortField))
+	} else {
+		session = session.Desc(util.SnakeString(sortField))
+	}
+
+	return session
+}
+
+// applyFreeTextSearch ANDs a free-text match for q onto session, across
+// searchColumns joined with OR. It prefers a Postgres tsvector column when
+// available, falling back to a LIKE scan per column otherwise.
+func applyFreeTextSearch(session *xorm.Session, q string, searchColumns []string, tsColumn string) *xorm.Session {
+	if adapter.driverName == "postgres" && tsColumn != "" {
+		if tsQuery := toTSQuery(q); tsQuery != "" {
+			return session.And(fmt.Sprintf("%s @@ to_tsquery(?)", tsColumn), tsQuery)
+		}
+	}
+
+	clauses := make([]string, 0, len(searchColumns))
+	args := make([]interface{}, 0, len(searchColumns))
+	for _, column := range searchColumns {
+		if !util.FilterField(column) {
+			continue
+		}
+		clauses = append(clauses, fmt.Sprintf("%s like ?", util.SnakeString(column)))
+		args = append(args, fmt.Sprintf("%%%s%%", q))
+	}
+	if len(clauses) == 0 {
+		return session
+	}
+
+	return session.And("("+strings.Join(clauses, " OR ")+")", args...)
+}
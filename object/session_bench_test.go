@@ -0,0 +1,130 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"xorm.io/xorm"
+)
+
+// benchRecord mirrors just the columns of Record that pagination cares
+// about, so these benchmarks don't depend on Record's full schema.
+type benchRecord struct {
+	Id          string `xorm:"pk varchar(100)"`
+	Owner       string `xorm:"varchar(100) index"`
+	CreatedTime string `xorm:"varchar(100) index"`
+}
+
+func (benchRecord) TableName() string {
+	return "record"
+}
+
+// benchRowCount is large enough to make offset pagination's O(offset) scan
+// cost visible next to cursor pagination's O(limit) seek cost.
+const benchRowCount = 1_000_000
+
+// seedBenchEngine creates an in-memory sqlite3 engine with n benchRecord
+// rows, ordered by CreatedTime.
+func seedBenchEngine(b *testing.B, n int) *xorm.Engine {
+	b.Helper()
+
+	engine, err := xorm.NewEngine("sqlite3", "file::memory:?cache=shared")
+	if err != nil {
+		b.Fatalf("failed to open benchmark engine: %v", err)
+	}
+	// The shared-cache memory database lives as long as a connection to it is
+	// open; close it after each run so the next run seeds a fresh database.
+	b.Cleanup(func() { engine.Close() })
+
+	if err := engine.Sync2(new(benchRecord)); err != nil {
+		b.Fatalf("failed to sync benchmark schema: %v", err)
+	}
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		b.Fatalf("failed to begin seed transaction: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		row := &benchRecord{
+			Id:          fmt.Sprintf("%010d", i),
+			Owner:       "admin",
+			CreatedTime: fmt.Sprintf("%020d", i),
+		}
+		if _, err := session.Insert(row); err != nil {
+			b.Fatalf("failed to seed row %d: %v", i, err)
+		}
+	}
+	if err := session.Commit(); err != nil {
+		b.Fatalf("failed to commit seed transaction: %v", err)
+	}
+
+	return engine
+}
+
+// BenchmarkGetSessionOffset and BenchmarkGetSessionCursor demonstrate the gap
+// GetSessionCursor closes: offset pagination re-scans and discards
+// `offset` rows on every page, so its cost grows with page depth, while
+// cursor pagination seeks directly through the created_time index
+// regardless of how deep the page is. Both benchmarks are skipped under
+// `-short`, since seeding benchRowCount rows is slow.
+func BenchmarkGetSessionOffset(b *testing.B) {
+	if testing.Short() {
+		b.Skip("skipping 1M-row pagination benchmark in -short mode")
+	}
+
+	prevAdapter := adapter
+	adapter = &Adapter{driverName: "sqlite3", engine: seedBenchEngine(b, benchRowCount)}
+	defer func() { adapter = prevAdapter }()
+
+	offset := benchRowCount - 100
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		session := GetSession("admin", offset, 20, "", "", "created_time", "descend")
+		var rows []benchRecord
+		if err := session.Find(&rows); err != nil {
+			b.Fatalf("offset query failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkGetSessionCursor(b *testing.B) {
+	if testing.Short() {
+		b.Skip("skipping 1M-row pagination benchmark in -short mode")
+	}
+
+	prevAdapter := adapter
+	adapter = &Adapter{driverName: "sqlite3", engine: seedBenchEngine(b, benchRowCount)}
+	defer func() { adapter = prevAdapter }()
+
+	cursor := EncodeCursor(fmt.Sprintf("%020d", benchRowCount-100), fmt.Sprintf("%010d", benchRowCount-100))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		session, err := GetSessionCursor("admin", cursor, 20, "created_time", "descend")
+		if err != nil {
+			b.Fatalf("cursor query setup failed: %v", err)
+		}
+		var rows []benchRecord
+		if err := session.Find(&rows); err != nil {
+			b.Fatalf("cursor query failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "testing"
+
+func TestEncodeDecodeCursorRoundTrip(t *testing.T) {
+	cursor := EncodeCursor("20240115093000", "rec-42")
+
+	payload, err := decodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("decodeCursor() returned error: %v", err)
+	}
+	if payload.SortValue != "20240115093000" || payload.Id != "rec-42" {
+		t.Errorf("decodeCursor() = %+v, want SortValue=20240115093000 Id=rec-42", payload)
+	}
+}
+
+func TestDecodeCursorEmpty(t *testing.T) {
+	payload, err := decodeCursor("")
+	if err != nil {
+		t.Fatalf("decodeCursor(\"\") returned error: %v", err)
+	}
+	if payload.SortValue != "" || payload.Id != "" {
+		t.Errorf("decodeCursor(\"\") = %+v, want zero value", payload)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	if _, err := decodeCursor("not-valid-base64!!"); err == nil {
+		t.Error("decodeCursor() with malformed input should return an error")
+	}
+}
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		q    string
+		want string
+	}{
+		{"single term", "login", "login"},
+		{"multi-word free text", "vpn login failure", "vpn & login & failure"},
+		{"strips tsquery operators", "vpn (login) & 'failure'*", "vpn & login & failure"},
+		{"collapses repeated whitespace", "  vpn   login  ", "vpn & login"},
+		{"all operators", "&|!()", ""},
+		{"empty", "", ""},
+	}
+
+	for _, test := range tests {
+		got := toTSQuery(test.q)
+		if got != test.want {
+			t.Errorf("%s: toTSQuery(%q) = %q, want %q", test.name, test.q, got, test.want)
+		}
+	}
+}